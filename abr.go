@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// abrRendition is one entry in the adaptive bitrate ladder.
+type abrRendition struct {
+	name    string
+	width   int
+	height  int
+	bitrate string
+}
+
+// abrLadder is ordered low to high; renditions taller than the source are
+// skipped, except that renditionsFor always keeps the lowest rung, so a
+// source shorter than 240p is upscaled to it.
+var abrLadder = []abrRendition{
+	{name: "240p", width: 426, height: 240, bitrate: "400k"},
+	{name: "480p", width: 854, height: 480, bitrate: "1000k"},
+	{name: "720p", width: 1280, height: 720, bitrate: "2500k"},
+	{name: "1080p", width: 1920, height: 1080, bitrate: "5000k"},
+}
+
+// abrPackage is the set of local files produced by packageAdaptiveBitrate,
+// ready to be uploaded as-is.
+type abrPackage struct {
+	dir              string
+	masterPlaylist   string
+	dashManifestPath string
+}
+
+func (p abrPackage) removeAll() {
+	os.RemoveAll(p.dir)
+}
+
+// getVideoDimensions probes filePath for its pixel width and height.
+func getVideoDimensions(filePath string) (width, height int, err error) {
+	type ffprobeOutput struct {
+		Streams []struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"streams"`
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_streams", filePath)
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return 0, 0, err
+	}
+
+	var outData ffprobeOutput
+	if err := json.Unmarshal(out.Bytes(), &outData); err != nil {
+		return 0, 0, err
+	}
+	if len(outData.Streams) == 0 {
+		return 0, 0, fmt.Errorf("no streams found")
+	}
+
+	return outData.Streams[0].Width, outData.Streams[0].Height, nil
+}
+
+// renditionsFor returns the ladder entries that fit within the source
+// resolution, always including at least the lowest rung.
+func renditionsFor(sourceHeight int) []abrRendition {
+	var variants []abrRendition
+	for _, r := range abrLadder {
+		if r.height <= sourceHeight {
+			variants = append(variants, r)
+		}
+	}
+	if len(variants) == 0 {
+		variants = []abrRendition{abrLadder[0]}
+	}
+	return variants
+}
+
+// packageAdaptiveBitrate runs ffmpeg once per rendition to produce an HLS
+// master playlist plus variant playlists/segments, and once more to produce
+// a DASH manifest covering the same ladder. Both are written under a fresh
+// temp directory the caller must remove via abrPackage.removeAll. onProgress
+// is called with 0-100 after each ffmpeg invocation completes, so callers
+// get real, if coarse-grained, progress instead of a fixed checkpoint.
+func packageAdaptiveBitrate(filePath string, sourceHeight int, onProgress func(percent float64)) (abrPackage, error) {
+	outputDir, err := os.MkdirTemp("", "abr-*")
+	if err != nil {
+		return abrPackage{}, err
+	}
+	pkg := abrPackage{dir: outputDir}
+
+	variants := renditionsFor(sourceHeight)
+	totalSteps := len(variants) + 1 // one ffmpeg run per variant, plus the DASH run
+	completedSteps := 0
+	reportStep := func() {
+		completedSteps++
+		onProgress(float64(completedSteps) / float64(totalSteps) * 100)
+	}
+
+	var master strings.Builder
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+
+	for _, r := range variants {
+		variantPlaylist := r.name + ".m3u8"
+		segmentPattern := r.name + "_%03d.ts"
+		cmd := exec.Command("ffmpeg", "-y", "-i", filePath,
+			"-vf", fmt.Sprintf("scale=-2:%d", r.height),
+			"-c:a", "aac", "-c:v", "libx264", "-b:v", r.bitrate,
+			"-hls_time", "6", "-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(outputDir, segmentPattern),
+			filepath.Join(outputDir, variantPlaylist),
+		)
+		if err := cmd.Run(); err != nil {
+			pkg.removeAll()
+			return abrPackage{}, err
+		}
+		reportStep()
+
+		master.WriteString(fmt.Sprintf(
+			"#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n%s\n",
+			bandwidthOf(r.bitrate), r.width, r.height, variantPlaylist,
+		))
+	}
+
+	masterPath := filepath.Join(outputDir, "master.m3u8")
+	if err := os.WriteFile(masterPath, []byte(master.String()), 0644); err != nil {
+		pkg.removeAll()
+		return abrPackage{}, err
+	}
+	pkg.masterPlaylist = masterPath
+
+	dashArgs := []string{"-y", "-i", filePath}
+	var maps []string
+	for i, r := range variants {
+		dashArgs = append(dashArgs,
+			"-map", "0:v:0", "-map", "0:a:0",
+			fmt.Sprintf("-filter:v:%d", i), fmt.Sprintf("scale=-2:%d", r.height),
+			fmt.Sprintf("-b:v:%d", i), r.bitrate,
+		)
+		maps = append(maps, fmt.Sprintf("v:%d,a:%d", i, i))
+	}
+	dashArgs = append(dashArgs,
+		"-c:a", "aac", "-c:v", "libx264", "-f", "dash",
+		"-adaptation_sets", fmt.Sprintf("id=0,streams=%s", strings.Join(maps, " ")),
+		filepath.Join(outputDir, "manifest.mpd"),
+	)
+	if err := exec.Command("ffmpeg", dashArgs...).Run(); err != nil {
+		pkg.removeAll()
+		return abrPackage{}, err
+	}
+	reportStep()
+	pkg.dashManifestPath = filepath.Join(outputDir, "manifest.mpd")
+
+	return pkg, nil
+}
+
+func bandwidthOf(bitrate string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(bitrate, "k"))
+	return n * 1000
+}
+
+// contentTypeForArtifact maps an ABR output file to the Content-Type it
+// should be served with.
+func contentTypeForArtifact(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".m3u8"):
+		return "application/vnd.apple.mpegurl"
+	case strings.HasSuffix(name, ".ts"):
+		return "video/mp2t"
+	case strings.HasSuffix(name, ".mpd"):
+		return "application/dash+xml"
+	case strings.HasSuffix(name, ".m4s"), strings.HasSuffix(name, ".mp4"):
+		return "video/mp4"
+	default:
+		return "application/octet-stream"
+	}
+}
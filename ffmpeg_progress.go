@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runFFmpegWithProgress runs ffmpeg with args plus "-progress pipe:1",
+// calling onProgress with 0-100 as ffmpeg reports out_time_ms against
+// totalDuration. If totalDuration is unknown (<= 0), onProgress is never
+// called; the command still runs to completion.
+func runFFmpegWithProgress(args []string, totalDuration time.Duration, onProgress func(percent float64)) error {
+	cmd := exec.Command("ffmpeg", append(args, "-progress", "pipe:1", "-nostats")...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || key != "out_time_ms" || totalDuration <= 0 {
+			continue
+		}
+
+		// Despite the name, ffmpeg reports out_time_ms in microseconds.
+		outTimeMicros, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		percent := float64(outTimeMicros) / float64(totalDuration.Microseconds()) * 100
+		if percent > 100 {
+			percent = 100
+		}
+		onProgress(percent)
+	}
+
+	return cmd.Wait()
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/media"
+	"github.com/google/uuid"
+	"github.com/kkdai/youtube/v2"
+)
+
+// defaultMaxImportHeight caps the resolution we'll pick from a YouTube
+// video's available formats when MAX_IMPORT_HEIGHT isn't set.
+const defaultMaxImportHeight = 1080
+
+// defaultMaxImportSize is the largest format size, in bytes, we'll download
+// from YouTube when MAX_IMPORT_SIZE isn't set. The limit is checked against
+// each format's advertised ContentLength: a format that reports no length is
+// not checked, and in the adaptive path the video and audio formats are
+// checked separately, not as a sum.
+const defaultMaxImportSize = 2 << 30 // 2 GiB
+
+type importYoutubeRequest struct {
+	YoutubeID string `json:"youtube_id"`
+}
+
+func (cfg *apiConfig) handlerImportYoutube(w http.ResponseWriter, r *http.Request) {
+	videoIDString := r.PathValue("videoID")
+	videoID, err := uuid.Parse(videoIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	dbVideo, err := cfg.db.GetVideo(videoID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error getting video", err)
+		return
+	}
+	if dbVideo.UserID != userID {
+		respondWithError(w, http.StatusUnauthorized, "User does not own video", nil)
+		return
+	}
+
+	var params importYoutubeRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body", err)
+		return
+	}
+	if params.YoutubeID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing youtube_id", nil)
+		return
+	}
+
+	fmt.Println("importing youtube video", params.YoutubeID, "for video", videoID)
+
+	client := youtube.Client{}
+	ytVideo, err := client.GetVideo(params.YoutubeID)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Error resolving YouTube video", err)
+		return
+	}
+
+	tempFilePath, err := cfg.downloadYoutubeVideo(&client, ytVideo)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Error downloading YouTube video", err)
+		return
+	}
+
+	dbVideo.Title = ytVideo.Title
+	dbVideo.Description = ytVideo.Description
+	if thumbnailURL := bestYoutubeThumbnail(ytVideo); thumbnailURL != "" {
+		// ThumbnailURL holds a FileStore key, so copy YouTube's thumbnail
+		// into the store instead of pointing at it directly.
+		if resp, err := http.Get(thumbnailURL); err == nil {
+			thumbnailKey := fmt.Sprintf("thumbnails/%s_youtube.jpg", videoID)
+			if resp.StatusCode == http.StatusOK && cfg.store.PutObject(r.Context(), thumbnailKey, resp.Body, resp.Header.Get("Content-Type"), "") == nil {
+				dbVideo.ThumbnailURL = &thumbnailKey
+			}
+			resp.Body.Close()
+		}
+	}
+	if err := cfg.db.UpdateVideo(dbVideo); err != nil {
+		os.Remove(tempFilePath)
+		respondWithError(w, http.StatusInternalServerError, "Error updating video", err)
+		return
+	}
+
+	jobID := uuid.New()
+	cfg.transcoder.Submit(media.Job{
+		ID:         jobID,
+		VideoID:    videoID,
+		SourcePath: tempFilePath,
+		MediaType:  "video/mp4",
+	})
+
+	respondWithJSON(w, http.StatusAccepted, struct {
+		JobID uuid.UUID `json:"job_id"`
+	}{JobID: jobID})
+}
+
+// maxImportHeight reads MAX_IMPORT_HEIGHT, falling back to
+// defaultMaxImportHeight when unset or invalid.
+func maxImportHeight() int {
+	if raw := os.Getenv("MAX_IMPORT_HEIGHT"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultMaxImportHeight
+}
+
+// maxImportSize reads MAX_IMPORT_SIZE (bytes), falling back to
+// defaultMaxImportSize when unset or invalid.
+func maxImportSize() int64 {
+	if raw := os.Getenv("MAX_IMPORT_SIZE"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultMaxImportSize
+}
+
+func bestYoutubeThumbnail(video *youtube.Video) string {
+	if len(video.Thumbnails) == 0 {
+		return ""
+	}
+	best := video.Thumbnails[0]
+	for _, t := range video.Thumbnails {
+		if t.Width > best.Width {
+			best = t
+		}
+	}
+	return best.URL
+}
+
+// downloadYoutubeVideo picks the highest-resolution progressive format under
+// maxImportHeight and streams it straight into the processed file
+// handlerUploadVideo would otherwise produce, the same starting point handed
+// off to the transcode job. Only when no progressive format exists under
+// that cap does it download the adaptive (video-only + audio-only) streams
+// and mux them with ffmpeg; a progressive format is used even when an
+// adaptive one of higher resolution exists under the cap. Both
+// paths are restricted to mp4-container formats (avc1/aac), since YouTube's
+// other adaptive formats are vp9/opus in a webm container and can't be
+// copy-muxed into an mp4.
+func (cfg *apiConfig) downloadYoutubeVideo(client *youtube.Client, video *youtube.Video) (string, error) {
+	heightCap := maxImportHeight()
+
+	if progressive := bestProgressiveFormat(video.Formats, heightCap); progressive != nil {
+		return cfg.downloadYoutubeProgressive(client, video, progressive)
+	}
+
+	videoFormat := bestFormat(video.Formats.Type("video/mp4"), heightCap)
+	audioFormat := bestFormat(video.Formats.Type("audio/mp4"), heightCap)
+	if videoFormat == nil || audioFormat == nil {
+		return "", fmt.Errorf("no usable mp4 formats under %dp for %s", heightCap, video.ID)
+	}
+
+	videoPath, err := cfg.downloadYoutubeFormat(client, video, videoFormat)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(videoPath)
+
+	audioPath, err := cfg.downloadYoutubeFormat(client, video, audioFormat)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(audioPath)
+
+	muxedFile, err := os.CreateTemp("", "youtube-import-*.mp4")
+	if err != nil {
+		return "", err
+	}
+	muxedFile.Close()
+
+	cmd := exec.Command("ffmpeg", "-y",
+		"-i", videoPath, "-i", audioPath,
+		"-c", "copy", "-movflags", "faststart",
+		muxedFile.Name(),
+	)
+	if err := cmd.Run(); err != nil {
+		os.Remove(muxedFile.Name())
+		return "", err
+	}
+
+	return muxedFile.Name(), nil
+}
+
+// downloadYoutubeProgressive pipes a progressive (audio+video) format
+// straight into ffmpeg's faststart remux, so the raw download never lands
+// on disk on its own; only the already-processed output does.
+func (cfg *apiConfig) downloadYoutubeProgressive(client *youtube.Client, video *youtube.Video, format *youtube.Format) (string, error) {
+	if format.ContentLength > 0 && format.ContentLength > maxImportSize() {
+		return "", fmt.Errorf("format %d is %d bytes, over the MAX_IMPORT_SIZE limit", format.ItagNo, format.ContentLength)
+	}
+
+	stream, _, err := client.GetStream(video, format)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	outFile, err := os.CreateTemp("", "youtube-import-*.mp4")
+	if err != nil {
+		return "", err
+	}
+	outFile.Close()
+
+	cmd := exec.Command("ffmpeg", "-y", "-i", "pipe:0", "-c", "copy", "-movflags", "faststart", "-f", "mp4", outFile.Name())
+	cmd.Stdin = stream
+	if err := cmd.Run(); err != nil {
+		os.Remove(outFile.Name())
+		return "", err
+	}
+
+	return outFile.Name(), nil
+}
+
+// downloadYoutubeFormat streams format to a temp file on disk. Used for the
+// adaptive fallback's video-only/audio-only components, which still need to
+// land on disk since muxing them together requires two seekable ffmpeg
+// inputs.
+func (cfg *apiConfig) downloadYoutubeFormat(client *youtube.Client, video *youtube.Video, format *youtube.Format) (string, error) {
+	if format.ContentLength > 0 && format.ContentLength > maxImportSize() {
+		return "", fmt.Errorf("format %d is %d bytes, over the MAX_IMPORT_SIZE limit", format.ItagNo, format.ContentLength)
+	}
+
+	stream, _, err := client.GetStream(video, format)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	tempFile, err := os.CreateTemp("", fmt.Sprintf("youtube-import-%d-*", format.ItagNo))
+	if err != nil {
+		return "", err
+	}
+	defer tempFile.Close()
+
+	if _, err := io.Copy(tempFile, stream); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return tempFile.Name(), nil
+}
+
+// bestProgressiveFormat returns the highest-resolution muxed (audio+video)
+// format at or under maxHeight, or nil if none qualify.
+func bestProgressiveFormat(formats youtube.FormatList, maxHeight int) *youtube.Format {
+	progressive := formats.Type("video/mp4").AudioChannels()
+	return bestFormat(progressive, maxHeight)
+}
+
+// bestFormat returns the highest-resolution entry at or under maxHeight.
+func bestFormat(formats youtube.FormatList, maxHeight int) *youtube.Format {
+	var candidates youtube.FormatList
+	for _, f := range formats {
+		if f.Height <= maxHeight {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Height > candidates[j].Height
+	})
+	return &candidates[0]
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/media"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerGetJob(w http.ResponseWriter, r *http.Request) {
+	jobIDString := r.PathValue("id")
+	jobID, err := uuid.Parse(jobIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	status, ok := cfg.transcoder.Status(jobID)
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Job not found", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobStatusResponse(jobID, status))
+}
+
+func (cfg *apiConfig) handlerStreamJob(w http.ResponseWriter, r *http.Request) {
+	jobIDString := r.PathValue("id")
+	jobID, err := uuid.Parse(jobIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			status, ok := cfg.transcoder.Status(jobID)
+			if !ok {
+				fmt.Fprintf(w, "event: error\ndata: job not found\n\n")
+				flusher.Flush()
+				return
+			}
+
+			data, err := json.Marshal(jobStatusResponse(jobID, status))
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+				flusher.Flush()
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+
+			if status.State == media.JobDone || status.State == media.JobFailed {
+				return
+			}
+		}
+	}
+}
+
+type jobStatus struct {
+	ID      uuid.UUID      `json:"id"`
+	State   media.JobState `json:"state"`
+	Percent float64        `json:"percent"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func jobStatusResponse(jobID uuid.UUID, status media.JobStatus) jobStatus {
+	resp := jobStatus{ID: jobID, State: status.State, Percent: status.Percent}
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+	return resp
+}
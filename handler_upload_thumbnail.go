@@ -4,9 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
 	"github.com/google/uuid"
@@ -82,27 +80,25 @@ func (cfg *apiConfig) handlerUploadThumbnail(w http.ResponseWriter, r *http.Requ
 
 	mediaType := header.Header.Get("Content-Type")
 	fileExt := mediaTypeToFileExtension(mediaType)
-	filePath := fmt.Sprintf("%s/%s.%s", cfg.assetsRoot, string(thumbnailID), fileExt)
+	key := fmt.Sprintf("thumbnails/%s.%s", string(thumbnailID), fileExt)
 
-	assetFile, err := os.Create(filePath)
+	err = cfg.store.PutObject(r.Context(), key, formFile, mediaType, "")
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error creating file", err)
+		respondWithError(w, http.StatusInternalServerError, "Error uploading thumbnail", err)
 		return
 	}
 
-	_, err = io.Copy(assetFile, formFile)
+	dbVideo.ThumbnailURL = &key
+	err = cfg.db.UpdateVideo(dbVideo)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error copying file", err)
+		respondWithError(w, http.StatusInternalServerError, "Error updating video", err)
 		return
 	}
 
-	thumbnailURL := fmt.Sprintf("http://localhost:%s/%s", cfg.port, filePath)
-	dbVideo.ThumbnailURL = &thumbnailURL
-	err = cfg.db.UpdateVideo(dbVideo)
+	dbVideo, err = cfg.dbVideoToSignedVideo(r.Context(), dbVideo)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error updating video", err)
+		respondWithError(w, http.StatusInternalServerError, "Error signing video URL", err)
 		return
 	}
-
 	respondWithJSON(w, http.StatusOK, dbVideo)
 }
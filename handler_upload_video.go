@@ -13,15 +13,21 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/database"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/filestore"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/media"
 	"github.com/google/uuid"
 )
 
+// uploadPartSize is the chunk size streamed per UploadPart call. S3 requires
+// every part but the last to be at least 5 MiB.
+const uploadPartSize = 8 << 20
+
 func mediaTypeToVideoExtension(mediaType string) string {
 	switch mediaType {
 	case "video/mp4":
@@ -86,11 +92,14 @@ func getVideoAspectRatio(filePath string) (string, error) {
 	}
 }
 
-func processVideoForFastStart(filePath string) (string, error) {
+// processVideoForFastStart remuxes filePath with the moov atom moved to the
+// front, reporting real transcode progress via onProgress (0-100) parsed
+// from ffmpeg's own "-progress pipe:1" output rather than a hardcoded
+// checkpoint.
+func processVideoForFastStart(filePath string, duration time.Duration, onProgress func(percent float64)) (string, error) {
 	outputFilePath := filePath + ".processing"
-	cmd := exec.Command("ffmpeg", "-i", filePath, "-c", "copy", "-movflags", "faststart", "-f", "mp4", outputFilePath)
-	err := cmd.Run()
-	if err != nil {
+	args := []string{"-i", filePath, "-c", "copy", "-movflags", "faststart", "-f", "mp4", outputFilePath}
+	if err := runFFmpegWithProgress(args, duration, onProgress); err != nil {
 		return "", err
 	}
 	return outputFilePath, nil
@@ -158,19 +167,44 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusInternalServerError, "Error creating temp file", err)
 		return
 	}
-	defer os.Remove(tempFile.Name())
 	defer tempFile.Close()
 
 	_, err = io.Copy(tempFile, formFile)
 	if err != nil {
+		os.Remove(tempFile.Name())
 		respondWithError(w, http.StatusInternalServerError, "Error copying file to temp", err)
 		return
 	}
 
-	aspectRatio, err := getVideoAspectRatio(tempFile.Name())
+	jobID := uuid.New()
+	cfg.transcoder.Submit(media.Job{
+		ID:         jobID,
+		VideoID:    videoID,
+		SourcePath: tempFile.Name(),
+		MediaType:  mediaType,
+	})
+
+	respondWithJSON(w, http.StatusAccepted, struct {
+		JobID uuid.UUID `json:"job_id"`
+	}{JobID: jobID})
+}
+
+// transcodeVideoJob is the media.WorkFunc run by cfg.transcoder for each
+// uploaded video. It replaces the inline probe/fast-start/upload sequence
+// that used to block handlerUploadVideo, and owns job.SourcePath, removing
+// it once finished regardless of outcome.
+func (cfg *apiConfig) transcodeVideoJob(ctx context.Context, job media.Job, report media.ReportFunc) error {
+	defer os.Remove(job.SourcePath)
+
+	report(media.JobProbing, 0)
+	dbVideo, err := cfg.db.GetVideo(job.VideoID)
+	if err != nil {
+		return err
+	}
+
+	aspectRatio, err := getVideoAspectRatio(job.SourcePath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error getting video aspect ratio", err)
-		return
+		return err
 	}
 	videoPrefix := "other"
 	switch aspectRatio {
@@ -180,99 +214,345 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 		videoPrefix = "portrait"
 	}
 
-	processedFilePath, err := processVideoForFastStart(tempFile.Name())
+	durationSeconds, err := getVideoDuration(job.SourcePath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error processing video for fast start", err)
-		return
+		return err
+	}
+	duration := time.Duration(durationSeconds * float64(time.Second))
+
+	report(media.JobTranscoding, 0)
+	processedFilePath, err := processVideoForFastStart(job.SourcePath, duration, func(percent float64) {
+		report(media.JobTranscoding, percent)
+	})
+	if err != nil {
+		return err
 	}
 	defer os.Remove(processedFilePath)
 	processedFile, err := os.Open(processedFilePath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error opening processed file", err)
-		return
+		return err
 	}
 	defer processedFile.Close()
 
-	rawVideoID := make([]byte, 32)
-	_, err = rand.Read(rawVideoID)
+	// Reuse a key from an earlier, interrupted upload of this video so the
+	// multipart upload below can resume instead of starting a new one.
+	var s3VideoKey string
+	if dbVideo.UploadKey != nil {
+		s3VideoKey = *dbVideo.UploadKey
+	} else {
+		rawVideoID := make([]byte, 32)
+		if _, err = rand.Read(rawVideoID); err != nil {
+			return err
+		}
+		s3VideoID := make([]byte, base64.RawURLEncoding.EncodedLen(len(rawVideoID)))
+		base64.RawURLEncoding.Encode(s3VideoID, rawVideoID)
+
+		s3VideoKey = fmt.Sprintf("%s/%s.%s", videoPrefix, s3VideoID, mediaTypeToVideoExtension(job.MediaType))
+		dbVideo.UploadKey = &s3VideoKey
+		if err := cfg.db.UpdateVideo(dbVideo); err != nil {
+			return err
+		}
+	}
+	s3VideoID := strings.TrimSuffix(filepath.Base(s3VideoKey), filepath.Ext(s3VideoKey))
+
+	report(media.JobUploading, 0)
+	resumeUploadID := ""
+	var resumeSize int64
+	if dbVideo.UploadID != nil {
+		resumeUploadID = *dbVideo.UploadID
+	}
+	if dbVideo.UploadSize != nil {
+		resumeSize = *dbVideo.UploadSize
+	}
+
+	progress := startUploadProgress(job.VideoID)
+	_, err = cfg.multipartUploadVideo(ctx, processedFile, s3VideoKey, job.MediaType, resumeUploadID, resumeSize, progress, func(uploadID string, size int64) error {
+		dbVideo.UploadID = &uploadID
+		dbVideo.UploadSize = &size
+		return cfg.db.UpdateVideo(dbVideo)
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error generating thumbnail ID", err)
-		return
+		progress.finish(err)
+		return err
+	}
+	progress.finish(nil)
+
+	// Upload finished; the multipart upload is no longer resumable bookkeeping.
+	dbVideo.UploadID = nil
+	dbVideo.UploadKey = nil
+	dbVideo.UploadSize = nil
+	if err := cfg.db.UpdateVideo(dbVideo); err != nil {
+		return err
 	}
-	s3VideoID := make([]byte, base64.RawURLEncoding.EncodedLen(len(rawVideoID)))
-	base64.RawURLEncoding.Encode(s3VideoID, rawVideoID)
 
-	s3VideoKey := fmt.Sprintf("%s/%s.%s", videoPrefix, s3VideoID, mediaTypeToVideoExtension(mediaType))
-	_, err = cfg.s3Client.PutObject(r.Context(), &s3.PutObjectInput{
-		Bucket:      &cfg.s3Bucket,
-		Key:         &s3VideoKey,
-		Body:        processedFile,
-		ContentType: &mediaType,
+	_, sourceHeight, err := getVideoDimensions(job.SourcePath)
+	if err != nil {
+		return err
+	}
+	pkg, err := packageAdaptiveBitrate(processedFilePath, sourceHeight, func(percent float64) {
+		report(media.JobTranscoding, percent)
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error uploading video to S3", err)
-		return
+		return err
 	}
+	defer pkg.removeAll()
 
-	videoURL := fmt.Sprintf("%s,%s", cfg.s3Bucket, s3VideoKey)
-	dbVideo.VideoURL = &videoURL
-	err = cfg.db.UpdateVideo(dbVideo)
+	abrPrefix := fmt.Sprintf("%s/%s", videoPrefix, s3VideoID)
+	masterKey, err := cfg.uploadABRPackage(ctx, pkg, abrPrefix)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error updating video", err)
-		return
+		return err
+	}
+
+	dbVideo.VideoURL = &masterKey
+
+	if err := cfg.generateThumbnails(ctx, &dbVideo, job.SourcePath, aspectRatio); err != nil {
+		return err
 	}
 
-	dbVideo, err = cfg.dbVideoToSignedVideo(r.Context(), dbVideo)
+	return cfg.db.UpdateVideo(dbVideo)
+}
+
+// generateThumbnails extracts a poster frame and a scrub-preview sprite
+// sheet from sourcePath and uploads both to cfg.store under thumbnails/<videoID>.
+// aspectRatio (as returned by getVideoAspectRatio) picks the poster/sprite
+// frame size so portrait video isn't stretched into a 16:9 box. It only
+// fills in dbVideo.ThumbnailURL if the user hasn't already uploaded one of
+// their own via handlerUploadThumbnail.
+func (cfg *apiConfig) generateThumbnails(ctx context.Context, dbVideo *database.Video, sourcePath, aspectRatio string) error {
+	duration, err := getVideoDuration(sourcePath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error signing video URL", err)
-		return
+		return err
+	}
+
+	posterPath, err := extractPosterFrame(sourcePath, duration, aspectRatio)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(posterPath)
+
+	spritePath, vttPath, err := generateSpriteSheet(sourcePath, duration, aspectRatio)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spritePath)
+	defer os.Remove(vttPath)
+
+	posterKey := fmt.Sprintf("thumbnails/%s.jpg", dbVideo.ID)
+	spriteKey := fmt.Sprintf("thumbnails/%s_sprite.jpg", dbVideo.ID)
+	vttKey := fmt.Sprintf("thumbnails/%s.vtt", dbVideo.ID)
+
+	if err := cfg.putFile(ctx, posterPath, posterKey, "image/jpeg", ""); err != nil {
+		return err
 	}
-	respondWithJSON(w, http.StatusOK, dbVideo)
+	if err := cfg.putFile(ctx, spritePath, spriteKey, "image/jpeg", ""); err != nil {
+		return err
+	}
+	if err := cfg.putFile(ctx, vttPath, vttKey, "text/vtt", ""); err != nil {
+		return err
+	}
+
+	// Re-read the stored thumbnail: the user may have uploaded one via
+	// handlerUploadThumbnail while this job was running.
+	if current, err := cfg.db.GetVideo(dbVideo.ID); err == nil {
+		dbVideo.ThumbnailURL = current.ThumbnailURL
+	}
+	if dbVideo.ThumbnailURL == nil {
+		dbVideo.ThumbnailURL = &posterKey
+	}
+	dbVideo.SpriteVTTURL = &vttKey
+	return nil
+}
+
+func (cfg *apiConfig) putFile(ctx context.Context, localPath, key, contentType, cacheControl string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return cfg.store.PutObject(ctx, key, file, contentType, cacheControl)
 }
 
 func (cfg *apiConfig) dbVideoToSignedVideo(
 	ctx context.Context,
 	video database.Video,
 ) (database.Video, error) {
-	if video.VideoURL == nil {
-		return video, nil
+	signedVideoURL, err := cfg.signRef(ctx, video.VideoURL)
+	if err != nil {
+		return video, err
 	}
+	video.VideoURL = signedVideoURL
 
-	components := strings.Split(*video.VideoURL, ",")
-	if len(components) < 2 {
-		return video, nil
+	signedThumbnailURL, err := cfg.signRef(ctx, video.ThumbnailURL)
+	if err != nil {
+		return video, err
 	}
+	video.ThumbnailURL = signedThumbnailURL
 
-	bucket := components[0]
-	key := components[1]
-	signedURL, err := generatePresignedURL(ctx, cfg.s3Client, bucket, key, 15*time.Minute)
+	signedSpriteVTTURL, err := cfg.signRef(ctx, video.SpriteVTTURL)
 	if err != nil {
 		return video, err
 	}
+	video.SpriteVTTURL = signedSpriteVTTURL
 
-	video.VideoURL = &signedURL
 	return video, nil
 }
 
-func generatePresignedURL(
+// signRef presigns a storage key through cfg.store. It's a no-op passthrough
+// for nil so callers can use it directly on optional *string fields.
+func (cfg *apiConfig) signRef(ctx context.Context, key *string) (*string, error) {
+	if key == nil {
+		return nil, nil
+	}
+
+	signedURL, err := cfg.store.PresignGet(ctx, *key, 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return &signedURL, nil
+}
+
+// PlaybackURL returns a presigned URL to video's master HLS playlist,
+// the same way dbVideoToSignedVideo signs the other video fields.
+func (cfg *apiConfig) PlaybackURL(ctx context.Context, video database.Video) (string, error) {
+	if video.VideoURL == nil {
+		return "", fmt.Errorf("video has no playback URL")
+	}
+	return cfg.store.PresignGet(ctx, *video.VideoURL, 15*time.Minute)
+}
+
+// abrCacheControl is applied to every HLS/DASH artifact: each one lives at
+// a content-addressed key (a fresh random video ID per upload) and is never
+// overwritten in place, so it's safe to cache for a long time.
+const abrCacheControl = "public, max-age=31536000, immutable"
+
+// uploadABRPackage uploads every file produced by packageAdaptiveBitrate
+// under prefix/, tagging each with the right Content-Type. It
+// returns the key of the HLS master playlist.
+func (cfg *apiConfig) uploadABRPackage(ctx context.Context, pkg abrPackage, prefix string) (string, error) {
+	entries, err := os.ReadDir(pkg.dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s", prefix, entry.Name())
+		if err := cfg.putFile(ctx, filepath.Join(pkg.dir, entry.Name()), key, contentTypeForArtifact(entry.Name()), abrCacheControl); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%s/master.m3u8", prefix), nil
+}
+
+// multipartUploadVideo streams src to the configured FileStore in
+// uploadPartSize chunks via its multipart upload API, reporting progress as
+// src is read into each part, instead of buffering the whole object in memory or
+// requiring a seekable PutObject body.
+//
+// If resumeUploadID is non-empty and resumeSize matches src's current size,
+// it reuses that in-progress upload rather than starting a new one: it
+// lists the parts already accepted via cfg.store.ListParts, seeks src past
+// the bytes they cover, and only uploads what's left. A size mismatch means
+// src isn't the same file the upload was started against, so it's treated
+// like no resumeUploadID was given. The same fallback applies if
+// cfg.store.ListParts itself fails - e.g. a LocalFileStore upload whose
+// in-memory state didn't survive a process restart - rather than failing
+// this video's uploads for good.
+//
+// Once it decides to start fresh, it calls onUploadStarted with the new
+// upload ID and src's size as soon as they're known, before streaming any
+// parts, so the caller can persist them and make this attempt itself
+// resumable if it's interrupted. A part-upload failure is left for the
+// caller to retry via a later resumeUploadID rather than aborted, since
+// aborting would delete the in-progress upload outright.
+func (cfg *apiConfig) multipartUploadVideo(
 	ctx context.Context,
-	s3Client *s3.Client,
-	bucket, key string,
-	expireTime time.Duration,
+	src *os.File,
+	key string,
+	mediaType string,
+	resumeUploadID string,
+	resumeSize int64,
+	progress *uploadProgress,
+	onUploadStarted func(uploadID string, size int64) error,
 ) (string, error) {
-	presignClient := s3.NewPresignClient(s3Client)
-	req, err := presignClient.PresignGetObject(
-		ctx,
-		&s3.GetObjectInput{
-			Bucket: &bucket,
-			Key:    &key,
-		},
-		s3.WithPresignExpires(expireTime),
-	)
+	info, err := src.Stat()
 	if err != nil {
 		return "", err
 	}
+	totalSize := info.Size()
+
+	uploadID := resumeUploadID
+	var completedParts []filestore.CompletedPart
+	if uploadID != "" && resumeSize == totalSize {
+		completedParts, err = cfg.store.ListParts(ctx, key, uploadID)
+		if err != nil {
+			uploadID = ""
+			completedParts = nil
+		}
+	} else {
+		uploadID = ""
+	}
+
+	if uploadID == "" {
+		uploadID, err = cfg.store.NewMultipartUpload(ctx, key, mediaType)
+		if err != nil {
+			return "", err
+		}
+		if err := onUploadStarted(uploadID, totalSize); err != nil {
+			cfg.store.AbortMultipartUpload(ctx, key, uploadID)
+			return "", err
+		}
+	}
+
+	var uploadedBytes int64
+	for _, part := range completedParts {
+		uploadedBytes += part.Size
+	}
+	if uploadedBytes > 0 {
+		if _, err := src.Seek(uploadedBytes, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+
+	reader := &progressReader{
+		Reader: src,
+		total:  totalSize,
+		read:   uploadedBytes,
+		onProgress: func(read, total int64) {
+			if total > 0 {
+				progress.set(float64(read) / float64(total) * 100)
+			}
+		},
+	}
+
+	for partNumber := int32(len(completedParts)) + 1; ; partNumber++ {
+		buf := make([]byte, uploadPartSize)
+		n, readErr := io.ReadFull(reader, buf)
+		if n == 0 && readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.ErrUnexpectedEOF && readErr != io.EOF {
+			return "", readErr
+		}
+
+		part, err := cfg.store.UploadPart(ctx, key, uploadID, partNumber, bytes.NewReader(buf[:n]))
+		if err != nil {
+			return "", err
+		}
+		completedParts = append(completedParts, part)
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	if err := cfg.store.CompleteMultipartUpload(ctx, key, uploadID, completedParts); err != nil {
+		return "", err
+	}
 
-	url := req.URL
-	return url, nil
+	return uploadID, nil
 }
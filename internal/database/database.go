@@ -0,0 +1,63 @@
+// Package database is a minimal JSON-file-backed store: good enough for the
+// tutorial, without requiring a real database server. Every write rewrites
+// the whole file under a mutex, which is fine at the scale this app runs at.
+package database
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// schema is the on-disk shape of the whole database file.
+type schema struct {
+	Videos map[string]Video `json:"videos"`
+	Jobs   map[string]Job   `json:"video_jobs"`
+}
+
+// Client is a handle to the JSON file backing the database. It's safe for
+// concurrent use.
+type Client struct {
+	path string
+	mu   *sync.RWMutex
+}
+
+// NewClient opens (creating if necessary) the JSON database file at path.
+func NewClient(path string) (Client, error) {
+	c := Client{path: path, mu: &sync.RWMutex{}}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := c.writeSchema(schema{Videos: map[string]Video{}, Jobs: map[string]Job{}}); err != nil {
+			return Client{}, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c Client) readSchema() (schema, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return schema{}, err
+	}
+
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return schema{}, err
+	}
+	if s.Videos == nil {
+		s.Videos = map[string]Video{}
+	}
+	if s.Jobs == nil {
+		s.Jobs = map[string]Job{}
+	}
+	return s, nil
+}
+
+func (c Client) writeSchema(s schema) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/media"
+	"github.com/google/uuid"
+)
+
+// Job is the persisted record backing a media.TranscodeService job, so
+// status survives a process restart instead of only living in memory.
+type Job struct {
+	ID        uuid.UUID      `json:"id"`
+	VideoID   uuid.UUID      `json:"video_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	State     media.JobState `json:"state"`
+	Percent   float64        `json:"percent"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// CreateJob inserts a queued row for job, satisfying media.JobStore.
+func (c Client) CreateJob(job media.Job) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	s.Jobs[job.ID.String()] = Job{
+		ID:        job.ID,
+		VideoID:   job.VideoID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		State:     media.JobQueued,
+	}
+	return c.writeSchema(s)
+}
+
+// UpdateJobStatus overwrites the stored status for jobID, satisfying
+// media.JobStore.
+func (c Client) UpdateJobStatus(jobID uuid.UUID, status media.JobStatus) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return err
+	}
+
+	row, ok := s.Jobs[jobID.String()]
+	if !ok {
+		return fmt.Errorf("job %s not found", jobID)
+	}
+
+	row.State = status.State
+	row.Percent = status.Percent
+	row.UpdatedAt = time.Now().UTC()
+	if status.Err != nil {
+		row.Error = status.Err.Error()
+	} else {
+		row.Error = ""
+	}
+
+	s.Jobs[jobID.String()] = row
+	return c.writeSchema(s)
+}
+
+// GetJobStatus returns the persisted status for jobID, satisfying
+// media.JobStore.
+func (c Client) GetJobStatus(jobID uuid.UUID) (media.JobStatus, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return media.JobStatus{}, err
+	}
+
+	row, ok := s.Jobs[jobID.String()]
+	if !ok {
+		return media.JobStatus{}, fmt.Errorf("job %s not found", jobID)
+	}
+
+	status := media.JobStatus{State: row.State, Percent: row.Percent}
+	if row.Error != "" {
+		status.Err = errors.New(row.Error)
+	}
+	return status, nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Video is a single video row. ThumbnailURL/VideoURL/SpriteVTTURL are keys
+// into the configured FileStore, not the signed URLs served to clients -
+// those are produced on the fly by apiConfig.dbVideoToSignedVideo.
+//
+// UploadID/UploadKey track an in-progress S3 multipart upload so it can be
+// resumed via FileStore.ListParts if the process is interrupted; both are
+// cleared once the upload completes. UploadSize is the source file size at
+// the time the upload started, so a resume attempt can tell whether it's
+// still looking at the same file before trusting UploadID's parts.
+type Video struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	UserID       uuid.UUID `json:"user_id"`
+	ThumbnailURL *string   `json:"thumbnail_url"`
+	VideoURL     *string   `json:"video_url"`
+	SpriteVTTURL *string   `json:"sprite_vtt_url"`
+	UploadID     *string   `json:"upload_id"`
+	UploadKey    *string   `json:"upload_key"`
+	UploadSize   *int64    `json:"upload_size"`
+}
+
+// CreateVideo inserts video, stamping CreatedAt/UpdatedAt.
+func (c Client) CreateVideo(video Video) (Video, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return Video{}, err
+	}
+
+	now := time.Now().UTC()
+	video.CreatedAt = now
+	video.UpdatedAt = now
+
+	s.Videos[video.ID.String()] = video
+	if err := c.writeSchema(s); err != nil {
+		return Video{}, err
+	}
+	return video, nil
+}
+
+// GetVideo looks up a video by ID.
+func (c Client) GetVideo(id uuid.UUID) (Video, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return Video{}, err
+	}
+
+	video, ok := s.Videos[id.String()]
+	if !ok {
+		return Video{}, fmt.Errorf("video %s not found", id)
+	}
+	return video, nil
+}
+
+// UpdateVideo overwrites the stored video with the same ID, stamping
+// UpdatedAt. The caller is expected to have loaded it via GetVideo first.
+func (c Client) UpdateVideo(video Video) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.Videos[video.ID.String()]; !ok {
+		return fmt.Errorf("video %s not found", video.ID)
+	}
+
+	video.UpdatedAt = time.Now().UTC()
+	s.Videos[video.ID.String()] = video
+	return c.writeSchema(s)
+}
+
+// DeleteVideo removes a video by ID.
+func (c Client) DeleteVideo(id uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.readSchema()
+	if err != nil {
+		return err
+	}
+
+	delete(s.Videos, id.String())
+	return c.writeSchema(s)
+}
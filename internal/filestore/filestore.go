@@ -0,0 +1,43 @@
+// Package filestore abstracts blob storage behind a single interface so the
+// rest of the app doesn't call an S3 client directly. This lets contributors
+// run the tutorial against a local directory or MinIO instead of requiring
+// real AWS credentials.
+package filestore
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// CompletedPart is one finished part of a multipart upload, returned by
+// UploadPart and ListParts and fed back into CompleteMultipartUpload. Size
+// lets a resumed upload skip re-reading bytes already on the backend.
+type CompletedPart struct {
+	PartNumber int32
+	ETag       string
+	Size       int64
+}
+
+// FileStore is the storage backend every handler uploads/downloads through.
+// Keys are backend-relative paths; a FileStore is always scoped to a single
+// bucket or root directory, so callers never need to know which backend
+// they're talking to.
+type FileStore interface {
+	// PutObject stores body under key. cacheControl is sent through verbatim
+	// as the object's Cache-Control (empty means no caching preference, i.e.
+	// whatever the backend defaults to).
+	PutObject(ctx context.Context, key string, body io.Reader, contentType, cacheControl string) error
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	DeleteObject(ctx context.Context, key string) error
+
+	NewMultipartUpload(ctx context.Context, key, contentType string) (uploadID string, err error)
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.Reader) (CompletedPart, error)
+	// ListParts returns the parts already accepted for an in-progress
+	// multipart upload, ordered by part number, so a caller can resume an
+	// interrupted upload instead of starting over.
+	ListParts(ctx context.Context, key, uploadID string) ([]CompletedPart, error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
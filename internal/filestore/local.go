@@ -0,0 +1,253 @@
+package filestore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LocalFileStore writes objects under a root directory on disk, for running
+// the tutorial without any cloud credentials. Reads are served by whatever
+// static file handler already serves assetsRoot; PresignGet instead returns
+// a short-lived HMAC-signed URL that LocalPresignMiddleware validates.
+type LocalFileStore struct {
+	root      string
+	baseURL   string
+	secretKey []byte
+}
+
+// NewLocalFileStore builds a FileStore rooted at root, serving presigned
+// URLs under baseURL (e.g. "http://localhost:8091/assets") and signing them
+// with secretKey.
+func NewLocalFileStore(root, baseURL string, secretKey []byte) *LocalFileStore {
+	return &LocalFileStore{root: root, baseURL: baseURL, secretKey: secretKey}
+}
+
+func (l *LocalFileStore) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(key))
+}
+
+// localCacheControl remembers the Cache-Control each key was PutObject'd
+// with, since the plain http.FileServer backing reads has no per-object
+// header of its own; LocalPresignMiddleware looks it up and sets it.
+var localCacheControl sync.Map // key -> string
+
+func (l *LocalFileStore) PutObject(ctx context.Context, key string, body io.Reader, contentType, cacheControl string) error {
+	fullPath := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, body); err != nil {
+		return err
+	}
+
+	if cacheControl != "" {
+		localCacheControl.Store(key, cacheControl)
+	} else {
+		localCacheControl.Delete(key)
+	}
+	return nil
+}
+
+func (l *LocalFileStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(l.path(key))
+}
+
+func (l *LocalFileStore) DeleteObject(ctx context.Context, key string) error {
+	return os.Remove(l.path(key))
+}
+
+// PresignGet signs key + an expiry timestamp with HMAC-SHA256 and returns a
+// URL for baseURL/key carrying both, mirroring what S3's presigned GETs give
+// callers.
+func (l *LocalFileStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	expires := time.Now().Add(ttl).Unix()
+	signature := l.sign(key, expires)
+
+	values := url.Values{}
+	values.Set("key", key)
+	values.Set("expires", strconv.FormatInt(expires, 10))
+	values.Set("signature", signature)
+
+	return fmt.Sprintf("%s/%s?%s", l.baseURL, key, values.Encode()), nil
+}
+
+func (l *LocalFileStore) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, l.secretKey)
+	fmt.Fprintf(mac, "%s:%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LocalPresignMiddleware validates the signed query params PresignGet put on
+// a URL before letting the request through to the static file handler. It
+// expects to be mounted with the baseURL path prefix stripped, so the
+// request path is the object key.
+func (l *LocalFileStore) LocalPresignMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		if r.URL.Path != key {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		expiresRaw := r.URL.Query().Get("expires")
+		signature := r.URL.Query().Get("signature")
+
+		expires, err := strconv.ParseInt(expiresRaw, 10, 64)
+		if err != nil || time.Now().Unix() > expires {
+			http.Error(w, "link expired", http.StatusForbidden)
+			return
+		}
+
+		if !hmac.Equal([]byte(signature), []byte(l.sign(key, expires))) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		if cacheControl, ok := localCacheControl.Load(key); ok {
+			w.Header().Set("Cache-Control", cacheControl.(string))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// multipartState tracks the parts written so far for a local "multipart"
+// upload, since the filesystem has no native equivalent.
+type multipartState struct {
+	dir   string
+	parts map[int32]string
+}
+
+var localMultipartUploads sync.Map // uploadID -> *multipartState
+
+func (l *LocalFileStore) NewMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	dir, err := os.MkdirTemp("", "local-multipart-*")
+	if err != nil {
+		return "", err
+	}
+
+	uploadID := filepath.Base(dir)
+	localMultipartUploads.Store(uploadID, &multipartState{dir: dir, parts: map[int32]string{}})
+	return uploadID, nil
+}
+
+func (l *LocalFileStore) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.Reader) (CompletedPart, error) {
+	state, err := loadMultipartState(uploadID)
+	if err != nil {
+		return CompletedPart{}, err
+	}
+
+	partPath := filepath.Join(state.dir, fmt.Sprintf("part-%d", partNumber))
+	file, err := os.Create(partPath)
+	if err != nil {
+		return CompletedPart{}, err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, body); err != nil {
+		return CompletedPart{}, err
+	}
+
+	state.parts[partNumber] = partPath
+	return CompletedPart{PartNumber: partNumber, ETag: partPath}, nil
+}
+
+// ListParts reports the parts recorded so far for uploadID. Local multipart
+// state only lives in process memory, so this can only resume an upload
+// interrupted mid-request, not one interrupted by a process restart.
+func (l *LocalFileStore) ListParts(ctx context.Context, key, uploadID string) ([]CompletedPart, error) {
+	state, err := loadMultipartState(uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	partNumbers := make([]int32, 0, len(state.parts))
+	for n := range state.parts {
+		partNumbers = append(partNumbers, n)
+	}
+	sort.Slice(partNumbers, func(i, j int) bool { return partNumbers[i] < partNumbers[j] })
+
+	parts := make([]CompletedPart, 0, len(partNumbers))
+	for _, n := range partNumbers {
+		info, err := os.Stat(state.parts[n])
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, CompletedPart{PartNumber: n, ETag: state.parts[n], Size: info.Size()})
+	}
+	return parts, nil
+}
+
+func loadMultipartState(uploadID string) (*multipartState, error) {
+	v, ok := localMultipartUploads.Load(uploadID)
+	if !ok {
+		return nil, fmt.Errorf("unknown local multipart upload %q", uploadID)
+	}
+	return v.(*multipartState), nil
+}
+
+func (l *LocalFileStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	state, err := loadMultipartState(uploadID)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(state.dir)
+	defer localMultipartUploads.Delete(uploadID)
+
+	sorted := append([]CompletedPart(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PartNumber < sorted[j].PartNumber })
+
+	fullPath := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	dest, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	for _, p := range sorted {
+		partPath, ok := state.parts[p.PartNumber]
+		if !ok {
+			return fmt.Errorf("missing local part %d for upload %q", p.PartNumber, uploadID)
+		}
+		part, err := os.Open(partPath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dest, part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *LocalFileStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	state, err := loadMultipartState(uploadID)
+	if err != nil {
+		return nil
+	}
+	localMultipartUploads.Delete(uploadID)
+	return os.RemoveAll(state.dir)
+}
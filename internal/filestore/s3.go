@@ -0,0 +1,169 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3FileStore implements FileStore against a real AWS S3 bucket.
+type S3FileStore struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3FileStore builds a FileStore against an AWS S3 bucket.
+func NewS3FileStore(client *s3.Client, bucket string) *S3FileStore {
+	return &S3FileStore{client: client, bucket: bucket}
+}
+
+// NewMinIOFileStore builds a FileStore against a MinIO (or any other
+// S3-compatible) server reachable at endpoint, using path-style addressing
+// since MinIO doesn't support virtual-hosted-style bucket URLs out of the box.
+func NewMinIOFileStore(cfg aws.Config, bucket, endpoint string) *S3FileStore {
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = true
+		o.BaseEndpoint = aws.String(endpoint)
+	})
+	return &S3FileStore{client: client, bucket: bucket}
+}
+
+func (s *S3FileStore) PutObject(ctx context.Context, key string, body io.Reader, contentType, cacheControl string) error {
+	input := &s3.PutObjectInput{
+		Bucket:      &s.bucket,
+		Key:         &key,
+		Body:        body,
+		ContentType: &contentType,
+	}
+	if cacheControl != "" {
+		input.CacheControl = &cacheControl
+	}
+	_, err := s.client.PutObject(ctx, input)
+	return err
+}
+
+func (s *S3FileStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3FileStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(s.client)
+	req, err := presignClient.PresignGetObject(
+		ctx,
+		&s3.GetObjectInput{
+			Bucket: &s.bucket,
+			Key:    &key,
+		},
+		s3.WithPresignExpires(ttl),
+	)
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+func (s *S3FileStore) DeleteObject(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	return err
+}
+
+func (s *S3FileStore) NewMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      &s.bucket,
+		Key:         &key,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return "", err
+	}
+	return *out.UploadId, nil
+}
+
+func (s *S3FileStore) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.Reader) (CompletedPart, error) {
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     &s.bucket,
+		Key:        &key,
+		UploadId:   &uploadID,
+		PartNumber: aws.Int32(partNumber),
+		Body:       body,
+	})
+	if err != nil {
+		return CompletedPart{}, err
+	}
+	return CompletedPart{PartNumber: partNumber, ETag: *out.ETag}, nil
+}
+
+func (s *S3FileStore) ListParts(ctx context.Context, key, uploadID string) ([]CompletedPart, error) {
+	var parts []CompletedPart
+	var partNumberMarker *string
+
+	for {
+		out, err := s.client.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:           &s.bucket,
+			Key:              &key,
+			UploadId:         &uploadID,
+			PartNumberMarker: partNumberMarker,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range out.Parts {
+			parts = append(parts, CompletedPart{
+				PartNumber: *p.PartNumber,
+				ETag:       *p.ETag,
+				Size:       *p.Size,
+			})
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			break
+		}
+		partNumberMarker = out.NextPartNumberMarker
+	}
+
+	return parts, nil
+}
+
+func (s *S3FileStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, p := range parts {
+		completed[i] = types.CompletedPart{
+			PartNumber: aws.Int32(p.PartNumber),
+			ETag:       aws.String(p.ETag),
+		}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: completed,
+		},
+	})
+	return err
+}
+
+func (s *S3FileStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+	return err
+}
@@ -0,0 +1,145 @@
+// Package media runs long-lived video processing work (probing, fast-start
+// remuxing, S3 upload) on a background worker pool so HTTP handlers can
+// return as soon as the job is queued instead of blocking on ffmpeg. Job
+// status is written through to an injected JobStore (backed by the
+// video_jobs table) so it survives a process restart, not just kept in
+// memory.
+package media
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// JobState is one step in a transcode job's lifecycle.
+type JobState string
+
+const (
+	JobQueued      JobState = "queued"
+	JobProbing     JobState = "probing"
+	JobTranscoding JobState = "transcoding"
+	JobUploading   JobState = "uploading"
+	JobDone        JobState = "done"
+	JobFailed      JobState = "failed"
+)
+
+// JobStatus is a point-in-time snapshot of a job, safe to copy.
+type JobStatus struct {
+	State   JobState
+	Percent float64
+	Err     error
+}
+
+// Job describes a unit of work submitted to a TranscodeService. VideoID is
+// the caller-supplied key used to look up status later; SourcePath and
+// MediaType are passed through untouched to whatever WorkFunc the service
+// was constructed with.
+type Job struct {
+	ID         uuid.UUID
+	VideoID    uuid.UUID
+	SourcePath string
+	MediaType  string
+}
+
+// ReportFunc lets a running job publish state/progress as it advances.
+type ReportFunc func(state JobState, percent float64)
+
+// WorkFunc performs the actual transcode for a job, calling report as it
+// moves through states.
+type WorkFunc func(ctx context.Context, job Job, report ReportFunc) error
+
+// JobStore persists job status across process restarts. TranscodeService
+// writes through to it on every state change and keeps an in-memory copy
+// for polling/streaming handlers so a healthy store is never on the read
+// path. A nil store leaves jobs in-memory only, for callers that don't wire
+// one up (e.g. tests).
+type JobStore interface {
+	CreateJob(job Job) error
+	UpdateJobStatus(jobID uuid.UUID, status JobStatus) error
+	GetJobStatus(jobID uuid.UUID) (JobStatus, error)
+}
+
+// TranscodeService runs jobs on a fixed-size worker pool and keeps the
+// latest status of each job in memory for polling/streaming handlers,
+// writing every change through to an optional JobStore so job status
+// survives a restart instead of only living in process memory. Jobs that
+// were queued or running when the process stopped are not re-run.
+type TranscodeService struct {
+	queue    chan Job
+	statuses sync.Map // uuid.UUID -> JobStatus
+	work     WorkFunc
+	store    JobStore
+}
+
+// NewTranscodeService starts workers goroutines pulling from an internal
+// queue, each running work for the jobs it dequeues. store may be nil.
+func NewTranscodeService(workers int, work WorkFunc, store JobStore) *TranscodeService {
+	s := &TranscodeService{
+		queue: make(chan Job, 64),
+		work:  work,
+		store: store,
+	}
+	for i := 0; i < workers; i++ {
+		go s.runWorker()
+	}
+	return s
+}
+
+func (s *TranscodeService) runWorker() {
+	for job := range s.queue {
+		s.process(job)
+	}
+}
+
+func (s *TranscodeService) process(job Job) {
+	report := func(state JobState, percent float64) {
+		s.setStatus(job.ID, JobStatus{State: state, Percent: percent})
+	}
+
+	if err := s.work(context.Background(), job, report); err != nil {
+		s.setStatus(job.ID, JobStatus{State: JobFailed, Err: err})
+		return
+	}
+	s.setStatus(job.ID, JobStatus{State: JobDone, Percent: 100})
+}
+
+// setStatus updates the in-memory cache and, if a JobStore is configured,
+// persists the same status so it survives a restart.
+func (s *TranscodeService) setStatus(jobID uuid.UUID, status JobStatus) {
+	s.statuses.Store(jobID, status)
+	if s.store != nil {
+		s.store.UpdateJobStatus(jobID, status)
+	}
+}
+
+// Submit enqueues job for processing and returns without waiting for it to
+// run; if the 64-job queue is full, it blocks until a worker frees a slot.
+// The caller is expected to have generated job.ID already so it can respond
+// with it.
+func (s *TranscodeService) Submit(job Job) {
+	status := JobStatus{State: JobQueued}
+	s.statuses.Store(job.ID, status)
+	if s.store != nil {
+		s.store.CreateJob(job)
+	}
+	s.queue <- job
+}
+
+// Status returns the latest known status for jobID, or false if unknown. It
+// checks the in-memory cache first and falls back to the JobStore, so a job
+// submitted before a restart is still visible afterward.
+func (s *TranscodeService) Status(jobID uuid.UUID) (JobStatus, bool) {
+	if v, ok := s.statuses.Load(jobID); ok {
+		return v.(JobStatus), true
+	}
+	if s.store == nil {
+		return JobStatus{}, false
+	}
+	status, err := s.store.GetJobStatus(jobID)
+	if err != nil {
+		return JobStatus{}, false
+	}
+	return status, true
+}
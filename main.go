@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/database"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/filestore"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/media"
+	"github.com/joho/godotenv"
+)
+
+// apiConfig holds the dependencies every handler needs: the JSON-backed DB,
+// the configured FileStore (local/S3/MinIO, picked by STORAGE_BACKEND), and
+// the background transcode pipeline.
+type apiConfig struct {
+	db         database.Client
+	jwtSecret  string
+	platform   string
+	port       string
+	store      filestore.FileStore
+	transcoder *media.TranscodeService
+}
+
+// transcodeWorkers is the size of the transcode worker pool; video uploads
+// run ffmpeg, which is CPU-bound, so this intentionally stays small.
+const transcodeWorkers = 2
+
+func newFileStore(port string) filestore.FileStore {
+	backend := os.Getenv("STORAGE_BACKEND")
+	bucket := os.Getenv("S3_BUCKET")
+
+	switch backend {
+	case "local", "":
+		assetsRoot := os.Getenv("ASSETS_ROOT")
+		if assetsRoot == "" {
+			assetsRoot = "assets"
+		}
+		if err := os.MkdirAll(assetsRoot, 0755); err != nil {
+			log.Fatalf("couldn't create assets root: %v", err)
+		}
+		baseURL := os.Getenv("ASSETS_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:" + port + "/assets"
+		}
+		secretKey := []byte(os.Getenv("LOCAL_PRESIGN_SECRET"))
+		return filestore.NewLocalFileStore(assetsRoot, baseURL, secretKey)
+
+	case "s3":
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			log.Fatalf("couldn't load AWS config: %v", err)
+		}
+		return filestore.NewS3FileStore(s3.NewFromConfig(awsCfg), bucket)
+
+	case "minio":
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			log.Fatalf("couldn't load AWS config: %v", err)
+		}
+		endpoint := os.Getenv("MINIO_ENDPOINT")
+		return filestore.NewMinIOFileStore(awsCfg, bucket, endpoint)
+
+	default:
+		log.Fatalf("unknown STORAGE_BACKEND %q", backend)
+		return nil
+	}
+}
+
+func main() {
+	godotenv.Load()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8091"
+	}
+
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "tubely.db.json"
+	}
+	db, err := database.NewClient(dbPath)
+	if err != nil {
+		log.Fatalf("couldn't connect to database: %v", err)
+	}
+
+	cfg := &apiConfig{
+		db:        db,
+		jwtSecret: os.Getenv("JWT_SECRET"),
+		platform:  os.Getenv("PLATFORM"),
+		port:      port,
+		store:     newFileStore(port),
+	}
+	// db persists the video_jobs table backing job status, so cfg.db
+	// doubles as the transcoder's media.JobStore.
+	cfg.transcoder = media.NewTranscodeService(transcodeWorkers, cfg.transcodeVideoJob, db)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/video_upload/{videoID}", cfg.handlerUploadVideo)
+	mux.HandleFunc("GET /api/videos/{videoID}/upload_progress", cfg.handlerGetUploadProgress)
+	mux.HandleFunc("POST /api/thumbnail_upload/{videoID}", cfg.handlerUploadThumbnail)
+	mux.HandleFunc("POST /api/videos/{videoID}/import_youtube", cfg.handlerImportYoutube)
+	mux.HandleFunc("GET /api/jobs/{id}", cfg.handlerGetJob)
+	mux.HandleFunc("GET /api/jobs/{id}/stream", cfg.handlerStreamJob)
+
+	if local, ok := cfg.store.(*filestore.LocalFileStore); ok {
+		assetsRoot := os.Getenv("ASSETS_ROOT")
+		if assetsRoot == "" {
+			assetsRoot = "assets"
+		}
+		assetsHandler := local.LocalPresignMiddleware(http.FileServer(http.Dir(assetsRoot)))
+		mux.Handle("/assets/", http.StripPrefix("/assets/", assetsHandler))
+	}
+
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
+
+	log.Printf("serving on port: %s", port)
+	log.Fatal(srv.ListenAndServe())
+}
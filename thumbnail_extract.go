@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// posterThumbnailWidth/Height match the ratio Clipper's thumbnail generator
+// uses for 16:9 source video.
+const (
+	posterThumbnailWidth  = 177
+	posterThumbnailHeight = 100
+
+	spriteFrameCount = 100
+)
+
+// thumbnailDimensions returns the poster/sprite-frame size to scale to for
+// aspectRatio (as returned by getVideoAspectRatio), so portrait uploads get a
+// portrait thumbnail instead of being stretched into the 16:9 box.
+func thumbnailDimensions(aspectRatio string) (width, height int) {
+	switch aspectRatio {
+	case "9:16":
+		return posterThumbnailHeight, posterThumbnailWidth
+	default:
+		return posterThumbnailWidth, posterThumbnailHeight
+	}
+}
+
+func getVideoDuration(filePath string) (float64, error) {
+	type ffprobeOutput struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_format", filePath)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return 0, err
+	}
+
+	var outData ffprobeOutput
+	if err := json.Unmarshal(out.Bytes(), &outData); err != nil {
+		return 0, err
+	}
+
+	var duration float64
+	if _, err := fmt.Sscanf(outData.Format.Duration, "%f", &duration); err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
+
+// extractPosterFrame grabs a single frame ~10% into the video, scaled per
+// thumbnailDimensions(aspectRatio), and writes it to a temp jpg the caller
+// must remove.
+func extractPosterFrame(filePath string, duration float64, aspectRatio string) (string, error) {
+	out, err := os.CreateTemp("", "poster-*.jpg")
+	if err != nil {
+		return "", err
+	}
+	out.Close()
+
+	width, height := thumbnailDimensions(aspectRatio)
+	timestamp := fmt.Sprintf("%.3f", duration*0.1)
+	cmd := exec.Command("ffmpeg", "-y",
+		"-ss", timestamp, "-i", filePath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d", width, height),
+		out.Name(),
+	)
+	if err := cmd.Run(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// generateSpriteSheet samples spriteFrameCount frames at a fixed interval,
+// each scaled per thumbnailDimensions(aspectRatio), tiles them into a single
+// image,
+// and writes a WebVTT file whose cues point at `#xywh=` fragments into that
+// sprite so the frontend can show hover-scrub previews without N separate
+// image requests. The caller must remove both returned paths.
+func generateSpriteSheet(filePath string, duration float64, aspectRatio string) (spritePath, vttPath string, err error) {
+	width, height := thumbnailDimensions(aspectRatio)
+	columns := int(math.Ceil(math.Sqrt(float64(spriteFrameCount))))
+	rows := int(math.Ceil(float64(spriteFrameCount) / float64(columns)))
+	interval := duration / float64(spriteFrameCount)
+	if interval <= 0 {
+		interval = duration
+	}
+
+	spriteOut, err := os.CreateTemp("", "sprite-*.jpg")
+	if err != nil {
+		return "", "", err
+	}
+	spriteOut.Close()
+
+	cmd := exec.Command("ffmpeg", "-y", "-i", filePath,
+		"-vf", fmt.Sprintf("fps=1/%f,scale=%d:%d,tile=%dx%d", interval, width, height, columns, rows),
+		"-frames:v", "1",
+		spriteOut.Name(),
+	)
+	if err := cmd.Run(); err != nil {
+		os.Remove(spriteOut.Name())
+		return "", "", err
+	}
+
+	vttOut, err := os.CreateTemp("", "sprite-*.vtt")
+	if err != nil {
+		os.Remove(spriteOut.Name())
+		return "", "", err
+	}
+	defer vttOut.Close()
+
+	spriteFileName := filepath.Base(spriteOut.Name())
+	fmt.Fprintln(vttOut, "WEBVTT")
+	fmt.Fprintln(vttOut)
+	for i := 0; i < spriteFrameCount; i++ {
+		start := formatVTTTimestamp(float64(i) * interval)
+		end := formatVTTTimestamp(float64(i+1) * interval)
+		x := (i % columns) * width
+		y := (i / columns) * height
+		fmt.Fprintf(vttOut, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			start, end, spriteFileName, x, y, width, height)
+	}
+
+	return spriteOut.Name(), vttOut.Name(), nil
+}
+
+func formatVTTTimestamp(seconds float64) string {
+	total := time.Duration(seconds * float64(time.Second))
+	hours := total / time.Hour
+	total -= hours * time.Hour
+	minutes := total / time.Minute
+	total -= minutes * time.Minute
+	secs := total / time.Second
+	total -= secs * time.Second
+	millis := total / time.Millisecond
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, secs, millis)
+}
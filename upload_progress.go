@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// progressReader wraps an io.Reader and reports bytes read against a known
+// total, so callers can surface upload/transcode progress without buffering
+// the underlying stream.
+type progressReader struct {
+	io.Reader
+	total      int64
+	read       int64
+	onProgress func(read, total int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.Reader.Read(p)
+	pr.read += int64(n)
+	if pr.onProgress != nil {
+		pr.onProgress(pr.read, pr.total)
+	}
+	return n, err
+}
+
+// uploadProgress tracks the state of a single in-flight video upload so the
+// SSE endpoint can poll it without coordinating directly with the uploading
+// goroutine.
+type uploadProgress struct {
+	videoID uuid.UUID
+	mu      sync.RWMutex
+	percent float64
+	done    bool
+	err     error
+}
+
+func (p *uploadProgress) set(percent float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.percent = percent
+}
+
+// finish marks the upload done and, after a grace period long enough for
+// handlerGetUploadProgress's 500ms poll to observe done (or err), drops it
+// from activeUploads unless a newer upload of the same video replaced it.
+func (p *uploadProgress) finish(err error) {
+	p.mu.Lock()
+	p.done = true
+	p.err = err
+	p.mu.Unlock()
+	time.AfterFunc(2*time.Second, func() {
+		activeUploads.CompareAndDelete(p.videoID, p)
+	})
+}
+
+func (p *uploadProgress) snapshot() (float64, bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.percent, p.done, p.err
+}
+
+var activeUploads sync.Map // videoID -> *uploadProgress
+
+func startUploadProgress(videoID uuid.UUID) *uploadProgress {
+	p := &uploadProgress{videoID: videoID}
+	activeUploads.Store(videoID, p)
+	return p
+}
+
+func (cfg *apiConfig) handlerGetUploadProgress(w http.ResponseWriter, r *http.Request) {
+	videoIDString := r.PathValue("videoID")
+	videoID, err := uuid.Parse(videoIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			value, ok := activeUploads.Load(videoID)
+			if !ok {
+				fmt.Fprintf(w, "event: error\ndata: no upload in progress\n\n")
+				flusher.Flush()
+				return
+			}
+
+			progress := value.(*uploadProgress)
+			percent, done, err := progress.snapshot()
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+				flusher.Flush()
+				return
+			}
+
+			fmt.Fprintf(w, "data: %.2f\n\n", percent)
+			flusher.Flush()
+
+			if done {
+				return
+			}
+		}
+	}
+}